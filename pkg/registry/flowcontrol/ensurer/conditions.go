@@ -0,0 +1,255 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	flowcontrolapi "k8s.io/kubernetes/pkg/apis/flowcontrol"
+)
+
+// Condition types the ensurer writes on the objects it manages. These
+// live alongside the scheduler/request-execution conditions the apiserver
+// itself may set, so they are deliberately namespaced to the bootstrap
+// ensurer's concerns. They are expressed in terms of the internal,
+// version-agnostic representation so the same condition logic applies
+// no matter which served version the ensurer is bootstrapping against.
+const (
+	// conditionManagedByBootstrap records whether the ensurer currently
+	// treats itself as the authoritative owner of the object's spec.
+	conditionManagedByBootstrap = "ManagedByBootstrap"
+	// conditionSpecInSync records whether the live spec matches the
+	// bootstrap spec (after defaulting).
+	conditionSpecInSync = "SpecInSync"
+	// conditionPendingDeletion marks an object that no longer appears
+	// in the current bootstrap set. GetFlowSchemaRemoveCandidates and
+	// GetPriorityLevelConfigurationRemoveCandidates set this on a first
+	// pass so operators have a window to notice before a later pass
+	// actually deletes the object.
+	conditionPendingDeletion = "PendingDeletion"
+
+	reasonAutoUpdateEnabled  = "AutoUpdateEnabled"
+	reasonAutoUpdateDisabled = "AutoUpdateDisabled"
+	reasonInSync             = "InSync"
+	reasonDrifted            = "Drifted"
+	reasonDangling           = "Dangling"
+	// reasonInBootstrap clears a PendingDeletion condition once an object
+	// previously marked dangling is back in the bootstrap set.
+	reasonInBootstrap = "InBootstrap"
+
+	// autoUpdateAnnotationKey lets an operator opt a managed object out
+	// of ensurer-driven updates. It is defined once here, rather than
+	// per served version, because its meaning doesn't vary by version.
+	autoUpdateAnnotationKey = "apf.kubernetes.io/autoupdate-spec"
+)
+
+func managedByBootstrapCondition(owned bool) flowcontrolapi.FlowSchemaCondition {
+	if !owned {
+		return flowcontrolapi.FlowSchemaCondition{
+			Type:   conditionManagedByBootstrap,
+			Status: flowcontrolapi.ConditionFalse,
+			Reason: reasonAutoUpdateDisabled,
+		}
+	}
+	return flowcontrolapi.FlowSchemaCondition{
+		Type:   conditionManagedByBootstrap,
+		Status: flowcontrolapi.ConditionTrue,
+		Reason: reasonAutoUpdateEnabled,
+	}
+}
+
+func specInSyncCondition(inSync bool, message string) flowcontrolapi.FlowSchemaCondition {
+	if !inSync {
+		return flowcontrolapi.FlowSchemaCondition{
+			Type:    conditionSpecInSync,
+			Status:  flowcontrolapi.ConditionFalse,
+			Reason:  reasonDrifted,
+			Message: message,
+		}
+	}
+	return flowcontrolapi.FlowSchemaCondition{
+		Type:   conditionSpecInSync,
+		Status: flowcontrolapi.ConditionTrue,
+		Reason: reasonInSync,
+	}
+}
+
+func pendingDeletionCondition() flowcontrolapi.FlowSchemaCondition {
+	return flowcontrolapi.FlowSchemaCondition{
+		Type:    conditionPendingDeletion,
+		Status:  flowcontrolapi.ConditionTrue,
+		Reason:  reasonDangling,
+		Message: "this object is no longer part of the bootstrap configuration and will be removed on a later reconciliation pass",
+	}
+}
+
+// pendingDeletionClearedCondition reverts a PendingDeletion condition a
+// prior dangling pass may have set, for an object that has since been
+// successfully re-ensured as part of the bootstrap set.
+func pendingDeletionClearedCondition() flowcontrolapi.FlowSchemaCondition {
+	return flowcontrolapi.FlowSchemaCondition{
+		Type:   conditionPendingDeletion,
+		Status: flowcontrolapi.ConditionFalse,
+		Reason: reasonInBootstrap,
+	}
+}
+
+// priorityLevelManagedByBootstrapCondition, priorityLevelSpecInSyncCondition and
+// priorityLevelPendingDeletionCondition mirror the FlowSchema condition
+// builders above; PriorityLevelConfigurationCondition is a distinct Go type
+// with the same fields, so the two families can't share a constructor.
+
+func priorityLevelManagedByBootstrapCondition(owned bool) flowcontrolapi.PriorityLevelConfigurationCondition {
+	if !owned {
+		return flowcontrolapi.PriorityLevelConfigurationCondition{
+			Type:   conditionManagedByBootstrap,
+			Status: flowcontrolapi.ConditionFalse,
+			Reason: reasonAutoUpdateDisabled,
+		}
+	}
+	return flowcontrolapi.PriorityLevelConfigurationCondition{
+		Type:   conditionManagedByBootstrap,
+		Status: flowcontrolapi.ConditionTrue,
+		Reason: reasonAutoUpdateEnabled,
+	}
+}
+
+func priorityLevelSpecInSyncCondition(inSync bool, message string) flowcontrolapi.PriorityLevelConfigurationCondition {
+	if !inSync {
+		return flowcontrolapi.PriorityLevelConfigurationCondition{
+			Type:    conditionSpecInSync,
+			Status:  flowcontrolapi.ConditionFalse,
+			Reason:  reasonDrifted,
+			Message: message,
+		}
+	}
+	return flowcontrolapi.PriorityLevelConfigurationCondition{
+		Type:   conditionSpecInSync,
+		Status: flowcontrolapi.ConditionTrue,
+		Reason: reasonInSync,
+	}
+}
+
+func priorityLevelPendingDeletionCondition() flowcontrolapi.PriorityLevelConfigurationCondition {
+	return flowcontrolapi.PriorityLevelConfigurationCondition{
+		Type:    conditionPendingDeletion,
+		Status:  flowcontrolapi.ConditionTrue,
+		Reason:  reasonDangling,
+		Message: "this object is no longer part of the bootstrap configuration and will be removed on a later reconciliation pass",
+	}
+}
+
+// priorityLevelPendingDeletionClearedCondition mirrors
+// pendingDeletionClearedCondition for PriorityLevelConfiguration.
+func priorityLevelPendingDeletionClearedCondition() flowcontrolapi.PriorityLevelConfigurationCondition {
+	return flowcontrolapi.PriorityLevelConfigurationCondition{
+		Type:   conditionPendingDeletion,
+		Status: flowcontrolapi.ConditionFalse,
+		Reason: reasonInBootstrap,
+	}
+}
+
+// driftMessage summarizes which top-level fields of a spec struct differ,
+// for use in the SpecInSync condition's message when auto-update is
+// disabled and the live spec has drifted from the bootstrap spec.
+func driftMessage(expectedSpec, actualSpec interface{}) string {
+	fields := diffTopLevelFields(expectedSpec, actualSpec)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("live spec differs from the bootstrap spec in field(s): %s", strings.Join(fields, ", "))
+}
+
+func diffTopLevelFields(expected, actual interface{}) []string {
+	expectedValue := reflect.ValueOf(expected)
+	actualValue := reflect.ValueOf(actual)
+
+	var diffs []string
+	structType := expectedValue.Type()
+	for i := 0; i < expectedValue.NumField(); i++ {
+		fieldName := structType.Field(i).Name
+		if !equality.Semantic.DeepEqual(expectedValue.Field(i).Interface(), actualValue.Field(i).Interface()) {
+			diffs = append(diffs, fieldName)
+		}
+	}
+	return diffs
+}
+
+// setFlowSchemaCondition upserts newCondition into status.Conditions,
+// matching on Type. LastTransitionTime only moves forward when Status
+// actually changes, the same semantics the apiserver's apihelpers
+// package uses for the versioned condition types.
+func setFlowSchemaCondition(status *flowcontrolapi.FlowSchemaStatus, newCondition flowcontrolapi.FlowSchemaCondition) {
+	for i := range status.Conditions {
+		existing := &status.Conditions[i]
+		if existing.Type != newCondition.Type {
+			continue
+		}
+		if existing.Status != newCondition.Status {
+			newCondition.LastTransitionTime = metav1.Now()
+		} else {
+			newCondition.LastTransitionTime = existing.LastTransitionTime
+		}
+		status.Conditions[i] = newCondition
+		return
+	}
+
+	newCondition.LastTransitionTime = metav1.Now()
+	status.Conditions = append(status.Conditions, newCondition)
+}
+
+// setPriorityLevelConfigurationCondition mirrors setFlowSchemaCondition
+// for PriorityLevelConfigurationCondition.
+func setPriorityLevelConfigurationCondition(status *flowcontrolapi.PriorityLevelConfigurationStatus, newCondition flowcontrolapi.PriorityLevelConfigurationCondition) {
+	for i := range status.Conditions {
+		existing := &status.Conditions[i]
+		if existing.Type != newCondition.Type {
+			continue
+		}
+		if existing.Status != newCondition.Status {
+			newCondition.LastTransitionTime = metav1.Now()
+		} else {
+			newCondition.LastTransitionTime = existing.LastTransitionTime
+		}
+		status.Conditions[i] = newCondition
+		return
+	}
+
+	newCondition.LastTransitionTime = metav1.Now()
+	status.Conditions = append(status.Conditions, newCondition)
+}
+
+// isAutoUpdateEnabled reports whether the given object allows
+// ensurer-managed updates, i.e. has not opted out via the well-known
+// auto-update annotation. Objects with no annotation, or an unparseable
+// one, default to enabled.
+func isAutoUpdateEnabled(accessor metav1.Object) bool {
+	value, ok := accessor.GetAnnotations()[autoUpdateAnnotationKey]
+	if !ok {
+		return true
+	}
+	autoUpdate, err := strconv.ParseBool(value)
+	if err != nil {
+		return true
+	}
+	return autoUpdate
+}
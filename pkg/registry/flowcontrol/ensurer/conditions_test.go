@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	flowcontrolapi "k8s.io/kubernetes/pkg/apis/flowcontrol"
+)
+
+func TestSetFlowSchemaConditionAppendsNewType(t *testing.T) {
+	status := &flowcontrolapi.FlowSchemaStatus{}
+
+	setFlowSchemaCondition(status, managedByBootstrapCondition(true))
+
+	if len(status.Conditions) != 1 {
+		t.Fatalf("got %d conditions, want 1", len(status.Conditions))
+	}
+	got := status.Conditions[0]
+	if got.Type != conditionManagedByBootstrap || got.Status != flowcontrolapi.ConditionTrue {
+		t.Fatalf("got %+v, want Type=%s Status=%s", got, conditionManagedByBootstrap, flowcontrolapi.ConditionTrue)
+	}
+	if got.LastTransitionTime.IsZero() {
+		t.Error("expected LastTransitionTime to be set for a newly appended condition")
+	}
+}
+
+func TestSetFlowSchemaConditionLastTransitionTime(t *testing.T) {
+	status := &flowcontrolapi.FlowSchemaStatus{}
+	setFlowSchemaCondition(status, managedByBootstrapCondition(true))
+	firstTransition := status.Conditions[0].LastTransitionTime
+
+	// Re-applying the same Status (True), but a different Reason/Message,
+	// must not move LastTransitionTime.
+	setFlowSchemaCondition(status, flowcontrolapi.FlowSchemaCondition{
+		Type:    conditionManagedByBootstrap,
+		Status:  flowcontrolapi.ConditionTrue,
+		Reason:  "SomeOtherReason",
+		Message: "something changed but not the status",
+	})
+	if len(status.Conditions) != 1 {
+		t.Fatalf("got %d conditions, want 1", len(status.Conditions))
+	}
+	if status.Conditions[0].Reason != "SomeOtherReason" {
+		t.Errorf("expected the Reason to be updated, got %q", status.Conditions[0].Reason)
+	}
+	if !status.Conditions[0].LastTransitionTime.Equal(&firstTransition) {
+		t.Error("LastTransitionTime must not move when Status is unchanged")
+	}
+
+	// Flipping Status must move LastTransitionTime to a fresh
+	// metav1.Now(). Clock granularity can make that compare equal to the
+	// first transition, so assert non-regression rather than strict
+	// inequality.
+	setFlowSchemaCondition(status, managedByBootstrapCondition(false))
+	if status.Conditions[0].Status != flowcontrolapi.ConditionFalse {
+		t.Fatalf("got Status=%s, want %s", status.Conditions[0].Status, flowcontrolapi.ConditionFalse)
+	}
+	if status.Conditions[0].LastTransitionTime.Before(&firstTransition) {
+		t.Error("LastTransitionTime must not move backwards when Status changes")
+	}
+}
+
+func TestSetFlowSchemaConditionMultipleTypes(t *testing.T) {
+	status := &flowcontrolapi.FlowSchemaStatus{}
+
+	setFlowSchemaCondition(status, managedByBootstrapCondition(true))
+	setFlowSchemaCondition(status, specInSyncCondition(true, ""))
+
+	if len(status.Conditions) != 2 {
+		t.Fatalf("got %d conditions, want 2 (one per distinct Type)", len(status.Conditions))
+	}
+}
+
+func TestPendingDeletionClearedConditionRevertsDangling(t *testing.T) {
+	status := &flowcontrolapi.FlowSchemaStatus{}
+	setFlowSchemaCondition(status, pendingDeletionCondition())
+
+	setFlowSchemaCondition(status, pendingDeletionClearedCondition())
+
+	if len(status.Conditions) != 1 {
+		t.Fatalf("got %d conditions, want 1", len(status.Conditions))
+	}
+	got := status.Conditions[0]
+	if got.Type != conditionPendingDeletion || got.Status != flowcontrolapi.ConditionFalse {
+		t.Fatalf("got %+v, want Type=%s Status=%s", got, conditionPendingDeletion, flowcontrolapi.ConditionFalse)
+	}
+}
+
+func TestIsAutoUpdateEnabled(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{
+		{name: "no annotation defaults to enabled", annotations: nil, want: true},
+		{name: "explicitly true", annotations: map[string]string{autoUpdateAnnotationKey: "true"}, want: true},
+		{name: "explicitly false", annotations: map[string]string{autoUpdateAnnotationKey: "false"}, want: false},
+		{name: "unparseable value defaults to enabled", annotations: map[string]string{autoUpdateAnnotationKey: "not-a-bool"}, want: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Annotations: tc.annotations}
+			if got := isAutoUpdateEnabled(obj); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDriftMessage(t *testing.T) {
+	type spec struct {
+		A string
+		B int
+	}
+
+	if msg := driftMessage(spec{A: "x", B: 1}, spec{A: "x", B: 1}); msg != "" {
+		t.Errorf("expected no drift message for identical specs, got %q", msg)
+	}
+
+	msg := driftMessage(spec{A: "x", B: 1}, spec{A: "y", B: 1})
+	if msg == "" {
+		t.Fatal("expected a non-empty drift message when a field differs")
+	}
+}
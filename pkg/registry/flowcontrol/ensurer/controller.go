@@ -0,0 +1,202 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// reconcileResyncPeriod bounds how long a watch event the informer
+// misses can delay reconciliation: even with no Add/Update/Delete
+// event, every bootstrap object gets re-enqueued at least this often.
+const reconcileResyncPeriod = 10 * time.Minute
+
+// bootstrapController drives informer-driven, workqueue-based
+// reconciliation for a single bootstrap kind. fsEnsurer and plcEnsurer
+// each embed one, supplying reconcileOne as the kind-specific unit of
+// work; the informer wiring, the workqueue, the worker goroutines and
+// the reconcile metrics are otherwise identical between the two kinds.
+type bootstrapController struct {
+	informer     cache.SharedIndexInformer
+	queue        workqueue.TypedRateLimitingInterface[string]
+	reconcileOne func(name string) error
+}
+
+// newResourceInformer builds a SharedIndexInformer watching resource (a
+// cluster-scoped FlowSchema or PriorityLevelConfiguration dynamic
+// resource client). It is split out of newBootstrapController so
+// multiple bootstrapControllers - one per ensurer registered against
+// the same resource - can share a single informer, and thus a single
+// List+Watch, via startOnceInformer instead of each building their own.
+func newResourceInformer(resource dynamic.NamespaceableResourceInterface) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return resource.List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return resource.Watch(context.TODO(), options)
+			},
+		},
+		&unstructured.Unstructured{},
+		reconcileResyncPeriod,
+		cache.Indexers{},
+	)
+}
+
+// startOnceInformer wraps a cache.SharedIndexInformer shared by several
+// bootstrapControllers so that only the first of their Run(ctx, workers)
+// calls actually starts its underlying reflector; later callers just
+// wait on the same HasSynced. Without this, each bootstrapController
+// sharing the informer would try to start it independently.
+type startOnceInformer struct {
+	cache.SharedIndexInformer
+	start sync.Once
+}
+
+func (s *startOnceInformer) Run(stopCh <-chan struct{}) {
+	s.start.Do(func() {
+		go s.SharedIndexInformer.Run(stopCh)
+	})
+}
+
+// newBootstrapController builds a controller around informer (typically
+// shared across several ensurers via startOnceInformer) and enqueues the
+// name of any object that changes. reconcileOne is called, possibly
+// concurrently, with the name of an object that needs reconciling; it is
+// responsible for deciding whether that name is still part of the
+// bootstrap set.
+func newBootstrapController(informer cache.SharedIndexInformer, queueName string, reconcileOne func(name string) error) *bootstrapController {
+	c := &bootstrapController{
+		informer: informer,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: queueName},
+		),
+		reconcileOne: reconcileOne,
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: c.enqueue,
+	})
+	return c
+}
+
+func (c *bootstrapController) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// run starts the informer and workers goroutines reconciling items off
+// the queue, blocking until ctx is done and every worker has returned.
+func (c *bootstrapController) run(ctx context.Context, workers int) {
+	go c.informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(c.runWorker, time.Second, ctx.Done())
+		}()
+	}
+
+	// Workers are blocked inside queue.Get() until either more work
+	// arrives or the queue is shut down; shutting it down here, as soon
+	// as ctx is canceled, is what lets them - and wg.Wait() below -
+	// actually return.
+	<-ctx.Done()
+	c.queue.ShutDown()
+	wg.Wait()
+}
+
+func (c *bootstrapController) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *bootstrapController) processNextWorkItem() bool {
+	name, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(name)
+
+	if err := c.reconcileAndObserve(name); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to reconcile %q: %w", name, err))
+		c.queue.AddRateLimited(name)
+		return true
+	}
+	c.queue.Forget(name)
+	return true
+}
+
+func (c *bootstrapController) reconcileAndObserve(name string) error {
+	start := time.Now()
+	err := c.reconcileOne(name)
+	observeReconcile(time.Since(start).Seconds(), err)
+	return err
+}
+
+// ensureSync synchronously reconciles names in the calling goroutine,
+// returning the first error encountered - the path Ensure uses to stay
+// a synchronous convenience even once Run has turned this controller's
+// queue into a long-running, concurrently-drained one.
+//
+// It deliberately does not enqueue into c.queue: that queue is drained
+// by Run's own worker goroutines, and a Run worker can dequeue and
+// finish a name before ensureSync gets back around to "waiting" for it,
+// since nothing would ever re-enqueue a name once a worker has already
+// handled it. That leaves ensureSync blocked forever on a name it will
+// never see again. Reconciling directly here, independent of whatever
+// Run's workers are doing with the same names concurrently, avoids the
+// race entirely - reconcileOne is idempotent, so the two racing to the
+// same outcome is harmless.
+func (c *bootstrapController) ensureSync(names []string) error {
+	var firstErr error
+	for _, name := range names {
+		if err := c.reconcileAndObserve(name); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to reconcile %q: %w", name, err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
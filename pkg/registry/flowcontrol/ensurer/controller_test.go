@@ -0,0 +1,191 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// newTestController builds a bootstrapController around reconcileOne
+// without an informer, for exercising the queue/worker plumbing in
+// isolation.
+func newTestController(reconcileOne func(name string) error) *bootstrapController {
+	return &bootstrapController{
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "test"},
+		),
+		reconcileOne: reconcileOne,
+	}
+}
+
+func TestProcessNextWorkItemSuccess(t *testing.T) {
+	var got string
+	c := newTestController(func(name string) error {
+		got = name
+		return nil
+	})
+	c.queue.Add("fs-1")
+
+	if more := c.processNextWorkItem(); !more {
+		t.Fatal("expected processNextWorkItem to report more work may remain")
+	}
+	if got != "fs-1" {
+		t.Errorf("reconcileOne called with %q, want %q", got, "fs-1")
+	}
+	if n := c.queue.NumRequeues("fs-1"); n != 0 {
+		t.Errorf("got %d requeues after a successful reconcile, want 0", n)
+	}
+}
+
+func TestProcessNextWorkItemRequeuesOnError(t *testing.T) {
+	wantErr := errors.New("boom")
+	calls := 0
+	c := newTestController(func(name string) error {
+		calls++
+		return wantErr
+	})
+	c.queue.Add("fs-1")
+
+	if more := c.processNextWorkItem(); !more {
+		t.Fatal("expected processNextWorkItem to report more work may remain")
+	}
+	if calls != 1 {
+		t.Fatalf("reconcileOne called %d times, want 1", calls)
+	}
+	if n := c.queue.NumRequeues("fs-1"); n != 1 {
+		t.Errorf("got %d requeues after a failed reconcile, want 1", n)
+	}
+}
+
+func TestProcessNextWorkItemShutdown(t *testing.T) {
+	c := newTestController(func(name string) error { return nil })
+	c.queue.ShutDown()
+
+	if more := c.processNextWorkItem(); more {
+		t.Error("expected processNextWorkItem to report no more work once the queue is shut down")
+	}
+}
+
+func TestEnsureSyncReconcilesEveryNameAndReturnsFirstError(t *testing.T) {
+	wantErr := errors.New("bootstrap failed")
+
+	var lock sync.Mutex
+	seen := map[string]int{}
+	c := newTestController(func(name string) error {
+		lock.Lock()
+		seen[name]++
+		lock.Unlock()
+		if name == "fails" {
+			return wantErr
+		}
+		return nil
+	})
+
+	err := c.ensureSync([]string{"ok-1", "fails", "ok-2"})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("got error %v, want %v", err, wantErr)
+	}
+	for _, name := range []string{"ok-1", "fails", "ok-2"} {
+		if seen[name] != 1 {
+			t.Errorf("reconcileOne called %d times for %q, want 1", seen[name], name)
+		}
+	}
+}
+
+func TestRunShutsDownOnContextCancellation(t *testing.T) {
+	// Regression test: run must return once ctx is canceled even with an
+	// empty queue, i.e. even though its worker goroutines are parked in
+	// queue.Get(). Before the fix this deadlocked forever, since the
+	// queue was only shut down via a deferred call that could not run
+	// until run() itself returned.
+	c := newTestController(func(name string) error { return nil })
+	c.informer = cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc:  func(_ metav1.ListOptions) (runtime.Object, error) { return &unstructured.UnstructuredList{}, nil },
+			WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return watch.NewFake(), nil },
+		},
+		&unstructured.Unstructured{},
+		0,
+		cache.Indexers{},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.run(ctx, 2)
+	}()
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		t.Fatal("informer never synced")
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return after ctx was canceled")
+	}
+}
+
+func TestEnsureSyncDoesNotShareStateWithConcurrentWorker(t *testing.T) {
+	// Regression test: ensureSync must not enqueue into c.queue and then
+	// wait on it, since a concurrently running Run worker draining the
+	// same queue could dequeue and finish a name before ensureSync gets
+	// back around to "waiting" for it, hanging ensureSync forever.
+	c := newTestController(func(name string) error { return nil })
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			c.processNextWorkItem()
+		}
+	}()
+	defer func() {
+		close(stop)
+		c.queue.ShutDown()
+		wg.Wait()
+	}()
+
+	// Also enqueue the same names a concurrent informer-driven worker
+	// might be racing to process.
+	c.queue.Add("fs-1")
+
+	if err := c.ensureSync([]string{"fs-1", "fs-2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -18,27 +18,82 @@ package ensurer
 
 import (
 	"context"
-	"errors"
+	"encoding/json"
 	"fmt"
+	"sync"
 
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	flowcontrolv1beta1 "k8s.io/api/flowcontrol/v1beta1"
+	flowcontrolv1beta2 "k8s.io/api/flowcontrol/v1beta2"
 	flowcontrolv1beta3 "k8s.io/api/flowcontrol/v1beta3"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
-	flowcontrolclient "k8s.io/client-go/kubernetes/typed/flowcontrol/v1beta3"
-	flowcontrollisters "k8s.io/client-go/listers/flowcontrol/v1beta3"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	flowcontrolapi "k8s.io/kubernetes/pkg/apis/flowcontrol"
+	flowcontrolapisv1 "k8s.io/kubernetes/pkg/apis/flowcontrol/v1"
+	flowcontrolapisv1beta1 "k8s.io/kubernetes/pkg/apis/flowcontrol/v1beta1"
+	flowcontrolapisv1beta2 "k8s.io/kubernetes/pkg/apis/flowcontrol/v1beta2"
 	flowcontrolapisv1beta3 "k8s.io/kubernetes/pkg/apis/flowcontrol/v1beta3"
+	"k8s.io/utils/ptr"
 )
 
-var (
-	errObjectNotFlowSchema = errors.New("object is not a FlowSchema type")
-)
+// flowSchemaCodecs maps each served version of FlowSchema the ensurer
+// knows about to the conversion/defaulting functions for that version.
+// Adding a new served version only means adding an entry here.
+var flowSchemaCodecs = map[string]versionCodec[*flowcontrolapi.FlowSchema]{
+	"v1": {
+		newExternal: func() runtime.Object { return &flowcontrolv1.FlowSchema{} },
+		defaultExternal: func(obj runtime.Object) {
+			flowcontrolapisv1.SetObjectDefaults_FlowSchema(obj.(*flowcontrolv1.FlowSchema))
+		},
+		newInternal: func() *flowcontrolapi.FlowSchema { return &flowcontrolapi.FlowSchema{} },
+	},
+	"v1beta3": {
+		newExternal: func() runtime.Object { return &flowcontrolv1beta3.FlowSchema{} },
+		defaultExternal: func(obj runtime.Object) {
+			flowcontrolapisv1beta3.SetObjectDefaults_FlowSchema(obj.(*flowcontrolv1beta3.FlowSchema))
+		},
+		newInternal: func() *flowcontrolapi.FlowSchema { return &flowcontrolapi.FlowSchema{} },
+	},
+	"v1beta2": {
+		newExternal: func() runtime.Object { return &flowcontrolv1beta2.FlowSchema{} },
+		defaultExternal: func(obj runtime.Object) {
+			flowcontrolapisv1beta2.SetObjectDefaults_FlowSchema(obj.(*flowcontrolv1beta2.FlowSchema))
+		},
+		newInternal: func() *flowcontrolapi.FlowSchema { return &flowcontrolapi.FlowSchema{} },
+	},
+	"v1beta1": {
+		newExternal: func() runtime.Object { return &flowcontrolv1beta1.FlowSchema{} },
+		defaultExternal: func(obj runtime.Object) {
+			flowcontrolapisv1beta1.SetObjectDefaults_FlowSchema(obj.(*flowcontrolv1beta1.FlowSchema))
+		},
+		newInternal: func() *flowcontrolapi.FlowSchema { return &flowcontrolapi.FlowSchema{} },
+	},
+}
 
-// FlowSchemaEnsurer ensures the specified bootstrap configuration objects
+// FlowSchemaEnsurer ensures the specified bootstrap configuration objects.
+// Bootstrap objects are expressed in the internal, version-agnostic
+// representation; the ensurer itself decides which served version to
+// speak on the wire.
 type FlowSchemaEnsurer interface {
-	Ensure([]*flowcontrolv1beta3.FlowSchema) error
+	// Ensure installs bootstrap as the current bootstrap set and
+	// synchronously reconciles every object in it, returning the first
+	// error encountered. FlowSchemas dropped from the bootstrap set
+	// relative to the previous call are passed to
+	// RemoveAutoUpdateEnabledObjects.
+	Ensure(bootstrap []*flowcontrolapi.FlowSchema) error
+
+	// Run starts the long-running reconciliation loop: it watches the
+	// live FlowSchema objects and keeps the bootstrap set in sync as
+	// user edits or object changes come in, using workers goroutines to
+	// drain the work queue. It blocks until ctx is done.
+	Run(ctx context.Context, workers int)
 }
 
 // FlowSchemaRemover is the interface that wraps the
@@ -52,48 +107,112 @@ type FlowSchemaRemover interface {
 }
 
 // NewSuggestedFlowSchemaEnsurer returns a FlowSchemaEnsurer instance that
-// can be used to ensure a set of suggested FlowSchema configuration objects.
-func NewSuggestedFlowSchemaEnsurer(client flowcontrolclient.FlowSchemaInterface, lister flowcontrollisters.FlowSchemaLister) FlowSchemaEnsurer {
-	wrapper := &flowSchemaWrapper{
-		client: client,
-		lister: lister,
-	}
-	return &fsEnsurer{
-		strategy: newSuggestedEnsureStrategy(wrapper),
-		wrapper:  wrapper,
-	}
+// can be used to ensure a set of suggested FlowSchema configuration
+// objects. Suggested objects respect the auto-update annotation: an
+// operator who sets it to false on one opts it out of further ensurer
+// writes.
+func NewSuggestedFlowSchemaEnsurer(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) (FlowSchemaEnsurer, error) {
+	return newFlowSchemaEnsurer(discoveryClient, dynamicClient, false)
 }
 
 // NewMandatoryFlowSchemaEnsurer returns a FlowSchemaEnsurer instance that
-// can be used to ensure a set of mandatory FlowSchema configuration objects.
-func NewMandatoryFlowSchemaEnsurer(client flowcontrolclient.FlowSchemaInterface, lister flowcontrollisters.FlowSchemaLister) FlowSchemaEnsurer {
+// can be used to ensure a set of mandatory FlowSchema configuration
+// objects. Mandatory objects are always owned by the ensurer.
+func NewMandatoryFlowSchemaEnsurer(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) (FlowSchemaEnsurer, error) {
+	return newFlowSchemaEnsurer(discoveryClient, dynamicClient, true)
+}
+
+// NewFlowSchemaRemover returns a FlowSchemaRemover instance that
+// can be used to remove a set of FlowSchema configuration objects.
+func NewFlowSchemaRemover(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) (FlowSchemaRemover, error) {
+	return newFlowSchemaEnsurer(discoveryClient, dynamicClient, false)
+}
+
+// newFlowSchemaEnsurer discovers the served FlowSchema version and
+// builds an fsEnsurer wired to talk that version over the wire while
+// callers keep working with the internal representation.
+func newFlowSchemaEnsurer(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, mandatory bool) (*fsEnsurer, error) {
+	version, err := discoverFlowControlVersion(discoveryClient)
+	if err != nil {
+		return nil, err
+	}
+	codec, ok := flowSchemaCodecs[version]
+	if !ok {
+		return nil, fmt.Errorf("no FlowSchema codec registered for discovered version %q", version)
+	}
+
 	wrapper := &flowSchemaWrapper{
-		client: client,
-		lister: lister,
+		resource: dynamicClient.Resource(flowSchemaResource(version)),
+		codec:    codec,
 	}
-	return &fsEnsurer{
-		strategy: newMandatoryEnsureStrategy(wrapper),
-		wrapper:  wrapper,
+	e := &fsEnsurer{
+		wrapper:   wrapper,
+		cache:     newSSACache(),
+		mandatory: mandatory,
+		bootstrap: map[string]*flowcontrolapi.FlowSchema{},
 	}
+	e.controller = newBootstrapController(sharedFlowSchemaInformer(dynamicClient, flowSchemaResource(version)), "flowschema_ensurer", e.reconcileOne)
+	return e, nil
 }
 
-// NewFlowSchemaRemover returns a FlowSchemaRemover instance that
-// can be used to remove a set of FlowSchema configuration objects.
-func NewFlowSchemaRemover(client flowcontrolclient.FlowSchemaInterface, lister flowcontrollisters.FlowSchemaLister) FlowSchemaRemover {
-	return &fsEnsurer{
-		wrapper: &flowSchemaWrapper{
-			client: client,
-			lister: lister,
-		},
+// sharedInformerKey identifies a shared informer by the dynamic client
+// and the resource it watches. Every dynamic.Interface.Resource call
+// returns a new resource client value, so that value can't be the key.
+type sharedInformerKey struct {
+	client   dynamic.Interface
+	resource schema.GroupVersionResource
+}
+
+// flowSchemaInformers caches one informer per dynamic client and
+// FlowSchema resource, so the suggested, mandatory and remover ensurers -
+// all three of which are expected to have Run called on them in a real
+// apiserver - share a single List+Watch against FlowSchemas instead of
+// each opening their own.
+var (
+	flowSchemaInformersMu sync.Mutex
+	flowSchemaInformers   = map[sharedInformerKey]*startOnceInformer{}
+)
+
+func sharedFlowSchemaInformer(dynamicClient dynamic.Interface, resource schema.GroupVersionResource) *startOnceInformer {
+	key := sharedInformerKey{client: dynamicClient, resource: resource}
+	flowSchemaInformersMu.Lock()
+	defer flowSchemaInformersMu.Unlock()
+
+	if informer, ok := flowSchemaInformers[key]; ok {
+		return informer
 	}
+	informer := &startOnceInformer{SharedIndexInformer: newResourceInformer(dynamicClient.Resource(resource))}
+	flowSchemaInformers[key] = informer
+	return informer
 }
 
 // GetFlowSchemaRemoveCandidates returns a list of FlowSchema object
 // names that are candidates for deletion from the cluster.
 // bootstrap: a set of hard coded FlowSchema configuration objects
-// kube-apiserver maintains in-memory.
-func GetFlowSchemaRemoveCandidates(lister flowcontrollisters.FlowSchemaLister, bootstrap []*flowcontrolv1beta3.FlowSchema) ([]string, error) {
-	fsList, err := lister.List(labels.Everything())
+// kube-apiserver maintains in-memory. Candidates are enumerated against
+// whichever version the server currently serves, so removal stays
+// correct even when that differs from the compiled-in bootstrap version.
+//
+// As a side effect, any dangling object that isn't already marked is
+// patched with a PendingDeletion condition and left out of the result:
+// only dangling objects marked by an earlier call are returned, giving
+// operators a window to notice an object before
+// RemoveAutoUpdateEnabledObjects removes it on a later pass.
+func GetFlowSchemaRemoveCandidates(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, bootstrap []*flowcontrolapi.FlowSchema) ([]string, error) {
+	version, err := discoverFlowControlVersion(discoveryClient)
+	if err != nil {
+		return nil, err
+	}
+	codec, ok := flowSchemaCodecs[version]
+	if !ok {
+		return nil, fmt.Errorf("no FlowSchema codec registered for discovered version %q", version)
+	}
+	wrapper := &flowSchemaWrapper{
+		resource: dynamicClient.Resource(flowSchemaResource(version)),
+		codec:    codec,
+	}
+
+	list, err := wrapper.resource.List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list FlowSchema - %w", err)
 	}
@@ -103,35 +222,187 @@ func GetFlowSchemaRemoveCandidates(lister flowcontrollisters.FlowSchemaLister, b
 		bootstrapNames.Insert(bootstrap[i].GetName())
 	}
 
-	currentObjects := make([]metav1.Object, len(fsList))
-	for i := range fsList {
-		currentObjects[i] = fsList[i]
+	currentObjects := make([]metav1.Object, 0, len(list.Items))
+	currentByName := make(map[string]*flowcontrolapi.FlowSchema, len(list.Items))
+	for i := range list.Items {
+		current, err := wrapper.codec.fromUnstructured(&list.Items[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert FlowSchema %q - %w", list.Items[i].GetName(), err)
+		}
+		currentObjects = append(currentObjects, current)
+		currentByName[current.GetName()] = current
+	}
+
+	danglingNames := getDanglingBootstrapObjectNames(bootstrapNames, currentObjects)
+	candidates := make([]string, 0, len(danglingNames))
+	for _, name := range danglingNames {
+		current := currentByName[name]
+		alreadyMarked := false
+		for _, condition := range current.Status.Conditions {
+			if condition.Type == conditionPendingDeletion && condition.Status == flowcontrolapi.ConditionTrue {
+				alreadyMarked = true
+			}
+		}
+		if alreadyMarked {
+			candidates = append(candidates, name)
+			continue
+		}
+		if err := wrapper.PatchConditions(current, []flowcontrolapi.FlowSchemaCondition{pendingDeletionCondition()}); err != nil {
+			return nil, fmt.Errorf("failed to mark FlowSchema %q as pending deletion - %w", name, err)
+		}
 	}
 
-	return getDanglingBootstrapObjectNames(bootstrapNames, currentObjects), nil
+	return candidates, nil
 }
 
 type fsEnsurer struct {
-	strategy ensureStrategy
-	wrapper  configurationWrapper
+	wrapper *flowSchemaWrapper
+	// cache lets Ensure skip the apply PATCH entirely when nothing has
+	// changed since the last successful apply for a given FlowSchema.
+	cache *ssaCache
+	// mandatory is true for the mandatory ensurer, which always owns
+	// the spec regardless of the auto-update annotation.
+	mandatory bool
+
+	// controller drives informer-based reconciliation once Run is
+	// called; Ensure also goes through it so the two stay consistent.
+	controller *bootstrapController
+
+	lock sync.RWMutex
+	// bootstrap is the most recently Ensure'd bootstrap set, keyed by
+	// name. reconcileOne consults it to decide whether a name the
+	// controller observed changing is still one the ensurer owns.
+	bootstrap map[string]*flowcontrolapi.FlowSchema
 }
 
-func (e *fsEnsurer) Ensure(flowSchemas []*flowcontrolv1beta3.FlowSchema) error {
+func (e *fsEnsurer) Ensure(flowSchemas []*flowcontrolapi.FlowSchema) error {
+	names := make([]string, 0, len(flowSchemas))
+	next := make(map[string]*flowcontrolapi.FlowSchema, len(flowSchemas))
 	for _, flowSchema := range flowSchemas {
 		// This code gets called by different goroutines. To avoid race conditions when
 		// https://github.com/kubernetes/kubernetes/blob/330b5a2b8dbd681811cb8235947557c99dd8e593/staging/src/k8s.io/apimachinery/pkg/runtime/helper.go#L221-L243
 		// temporarily modifies the TypeMeta, we have to make a copy here.
-		if err := ensureConfiguration(e.wrapper, e.strategy, flowSchema.DeepCopy()); err != nil {
+		copied := flowSchema.DeepCopy()
+		next[copied.Name] = copied
+		names = append(names, copied.Name)
+	}
+
+	e.lock.Lock()
+	previous := e.bootstrap
+	e.bootstrap = next
+	e.lock.Unlock()
+
+	ensureErr := e.controller.ensureSync(names)
+
+	var dropped []string
+	for name := range previous {
+		if _, ok := next[name]; !ok {
+			dropped = append(dropped, name)
+		}
+	}
+	if len(dropped) == 0 {
+		return ensureErr
+	}
+	if err := e.RemoveAutoUpdateEnabledObjects(dropped); err != nil && ensureErr == nil {
+		ensureErr = err
+	}
+	return ensureErr
+}
+
+// Run starts the informer-driven reconciliation loop and blocks until
+// ctx is done.
+func (e *fsEnsurer) Run(ctx context.Context, workers int) {
+	e.controller.run(ctx, workers)
+}
+
+// reconcileOne is the unit of work the controller's workers and Ensure's
+// own synchronous drain both call for a given FlowSchema name. A name
+// that has fallen out of the bootstrap set is not this ensurer's
+// concern - RemoveAutoUpdateEnabledObjects, not the controller, is
+// responsible for removing it.
+func (e *fsEnsurer) reconcileOne(name string) error {
+	e.lock.RLock()
+	bootstrap, ok := e.bootstrap[name]
+	e.lock.RUnlock()
+	if !ok {
+		return nil
+	}
+	return e.ensureOne(bootstrap.DeepCopy())
+}
+
+// ensureOne applies a single bootstrap FlowSchema via server-side apply,
+// skipping the PATCH call altogether when e.cache shows the live object
+// already reflects this bootstrap spec. It also keeps the object's
+// ManagedByBootstrap and SpecInSync conditions up to date.
+func (e *fsEnsurer) ensureOne(bootstrap *flowcontrolapi.FlowSchema) error {
+	name := bootstrap.GetName()
+
+	current, err := e.wrapper.Get(name)
+	notFound := apierrors.IsNotFound(err)
+	if err != nil && !notFound {
+		return err
+	}
+
+	autoUpdate := true
+	var resourceVersion string
+	if !notFound {
+		resourceVersion = current.GetResourceVersion()
+		if !e.mandatory {
+			autoUpdate = isAutoUpdateEnabled(current)
+		}
+	}
+
+	if !autoUpdate {
+		defaultedBootstrap, err := e.wrapper.Defaulted(bootstrap)
+		if err != nil {
 			return err
 		}
+		specChanged := !equality.Semantic.DeepEqual(defaultedBootstrap.Spec, current.Spec)
+		var message string
+		if specChanged {
+			message = driftMessage(defaultedBootstrap.Spec, current.Spec)
+		}
+		return e.wrapper.PatchConditions(current, []flowcontrolapi.FlowSchemaCondition{
+			managedByBootstrapCondition(false),
+			specInSyncCondition(!specChanged, message),
+		})
 	}
 
-	return nil
+	defaultedBootstrap, err := e.wrapper.Defaulted(bootstrap)
+	if err != nil {
+		return err
+	}
+
+	hash, err := ssaHash(resourceVersion, defaultedBootstrap.Spec)
+	if err != nil {
+		return err
+	}
+	if cached, ok := e.cache.Get(name); ok && cached == hash {
+		return nil
+	}
+
+	applied, err := e.wrapper.Apply(bootstrap)
+	if err != nil {
+		e.cache.Evict(name)
+		return err
+	}
+
+	newHash, err := ssaHash(applied.GetResourceVersion(), defaultedBootstrap.Spec)
+	if err != nil {
+		return err
+	}
+	e.cache.Set(name, newHash)
+
+	return e.wrapper.PatchConditions(applied, []flowcontrolapi.FlowSchemaCondition{
+		managedByBootstrapCondition(true),
+		specInSyncCondition(true, ""),
+		pendingDeletionClearedCondition(),
+	})
 }
 
 func (e *fsEnsurer) RemoveAutoUpdateEnabledObjects(flowSchemas []string) error {
-	for _, flowSchema := range flowSchemas {
-		if err := removeAutoUpdateEnabledConfiguration(e.wrapper, flowSchema); err != nil {
+	for _, name := range flowSchemas {
+		if err := e.removeOne(name); err != nil {
 			return err
 		}
 	}
@@ -139,73 +410,98 @@ func (e *fsEnsurer) RemoveAutoUpdateEnabledObjects(flowSchemas []string) error {
 	return nil
 }
 
+func (e *fsEnsurer) removeOne(name string) error {
+	current, err := e.wrapper.Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !isAutoUpdateEnabled(current) {
+		return nil
+	}
+	if err := e.wrapper.Delete(name); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 // flowSchemaWrapper abstracts all FlowSchema specific logic, with this
-// we can manage all boiler plate code in one place.
+// we can manage all boiler plate code in one place. It speaks whichever
+// served version codec was resolved at construction time, translating
+// to and from the internal representation at its boundary.
 type flowSchemaWrapper struct {
-	client flowcontrolclient.FlowSchemaInterface
-	lister flowcontrollisters.FlowSchemaLister
+	resource dynamic.NamespaceableResourceInterface
+	codec    versionCodec[*flowcontrolapi.FlowSchema]
 }
 
 func (fs *flowSchemaWrapper) TypeName() string {
 	return "FlowSchema"
 }
 
-func (fs *flowSchemaWrapper) Create(object runtime.Object) (runtime.Object, error) {
-	fsObject, ok := object.(*flowcontrolv1beta3.FlowSchema)
-	if !ok {
-		return nil, errObjectNotFlowSchema
+// Apply issues a server-side apply PATCH with the bootstrap object as
+// the intent, creating the FlowSchema if it does not yet exist.
+func (fs *flowSchemaWrapper) Apply(bootstrap *flowcontrolapi.FlowSchema) (*flowcontrolapi.FlowSchema, error) {
+	u, err := fs.codec.toUnstructured(bootstrap)
+	if err != nil {
+		return nil, err
 	}
 
-	return fs.client.Create(context.TODO(), fsObject, metav1.CreateOptions{FieldManager: fieldManager})
-}
-
-func (fs *flowSchemaWrapper) Update(object runtime.Object) (runtime.Object, error) {
-	fsObject, ok := object.(*flowcontrolv1beta3.FlowSchema)
-	if !ok {
-		return nil, errObjectNotFlowSchema
+	body, err := json.Marshal(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal FlowSchema %q for server-side apply - %w", bootstrap.Name, err)
 	}
 
-	return fs.client.Update(context.TODO(), fsObject, metav1.UpdateOptions{FieldManager: fieldManager})
-}
-
-func (fs *flowSchemaWrapper) Get(name string) (configurationObject, error) {
-	return fs.lister.Get(name)
-}
-
-func (fs *flowSchemaWrapper) Delete(name string) error {
-	return fs.client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	applied, err := fs.resource.Patch(context.TODO(), bootstrap.Name, types.ApplyPatchType, body, metav1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        ptr.To(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fs.codec.fromUnstructured(applied)
 }
 
-func (fs *flowSchemaWrapper) CopySpec(bootstrap, current runtime.Object) error {
-	bootstrapFS, ok := bootstrap.(*flowcontrolv1beta3.FlowSchema)
-	if !ok {
-		return errObjectNotFlowSchema
+// PatchConditions applies conditions to object's status. LastTransitionTime
+// only moves when Status changes, and no API call is issued when the
+// resulting status is unchanged.
+func (fs *flowSchemaWrapper) PatchConditions(object *flowcontrolapi.FlowSchema, conditions []flowcontrolapi.FlowSchemaCondition) error {
+	updated := object.DeepCopy()
+	for _, condition := range conditions {
+		setFlowSchemaCondition(&updated.Status, condition)
 	}
-	currentFS, ok := current.(*flowcontrolv1beta3.FlowSchema)
-	if !ok {
-		return errObjectNotFlowSchema
+	if equality.Semantic.DeepEqual(updated.Status, object.Status) {
+		return nil
 	}
 
-	specCopy := bootstrapFS.Spec.DeepCopy()
-	currentFS.Spec = *specCopy
-	return nil
+	u, err := fs.codec.toUnstructured(updated)
+	if err != nil {
+		return err
+	}
+	_, err = fs.resource.UpdateStatus(context.TODO(), u, metav1.UpdateOptions{FieldManager: fieldManager})
+	return err
 }
 
-func (fs *flowSchemaWrapper) HasSpecChanged(bootstrap, current runtime.Object) (bool, error) {
-	bootstrapFS, ok := bootstrap.(*flowcontrolv1beta3.FlowSchema)
-	if !ok {
-		return false, errObjectNotFlowSchema
-	}
-	currentFS, ok := current.(*flowcontrolv1beta3.FlowSchema)
-	if !ok {
-		return false, errObjectNotFlowSchema
+func (fs *flowSchemaWrapper) Get(name string) (*flowcontrolapi.FlowSchema, error) {
+	u, err := fs.resource.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
 	}
+	return fs.codec.fromUnstructured(u)
+}
 
-	return flowSchemaSpecChanged(bootstrapFS, currentFS), nil
+func (fs *flowSchemaWrapper) Delete(name string) error {
+	return fs.resource.Delete(context.TODO(), name, metav1.DeleteOptions{})
 }
 
-func flowSchemaSpecChanged(expected, actual *flowcontrolv1beta3.FlowSchema) bool {
-	copiedExpectedFlowSchema := expected.DeepCopy()
-	flowcontrolapisv1beta3.SetObjectDefaults_FlowSchema(copiedExpectedFlowSchema)
-	return !equality.Semantic.DeepEqual(copiedExpectedFlowSchema.Spec, actual.Spec)
+// Defaulted runs bootstrap through the served version's defaulting, so
+// callers can compare apples-to-apples regardless of which version the
+// bootstrap spec was authored against. Callers that need both a
+// changed/unchanged verdict and a human-readable drift description must
+// derive both from this same defaulted object - diffing the raw,
+// un-defaulted bootstrap spec against current can disagree with the
+// defaulted comparison and point at the wrong field.
+func (fs *flowSchemaWrapper) Defaulted(bootstrap *flowcontrolapi.FlowSchema) (*flowcontrolapi.FlowSchema, error) {
+	return fs.codec.defaulted(bootstrap)
 }
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	reconcileResultSuccess = "success"
+	reconcileResultError   = "error"
+)
+
+var (
+	reconcileTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "apiserver",
+			Subsystem:      "flowcontrol",
+			Name:           "bootstrap_reconcile_total",
+			Help:           "Number of bootstrap FlowSchema/PriorityLevelConfiguration reconciliations by the ensurer controllers, labeled by result",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	reconcileDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      "apiserver",
+			Subsystem:      "flowcontrol",
+			Name:           "bootstrap_reconcile_duration_seconds",
+			Help:           "Duration in seconds of a single bootstrap object reconciliation by the ensurer controllers",
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(reconcileTotal)
+	legacyregistry.MustRegister(reconcileDuration)
+}
+
+// observeReconcile records the outcome and duration of a single
+// reconcile call against the apiserver_flowcontrol_bootstrap_reconcile_*
+// metrics, shared by the FlowSchema and PriorityLevelConfiguration
+// controllers.
+func observeReconcile(durationSeconds float64, err error) {
+	result := reconcileResultSuccess
+	if err != nil {
+		result = reconcileResultError
+	}
+	reconcileTotal.WithLabelValues(result).Inc()
+	reconcileDuration.WithLabelValues(result).Observe(durationSeconds)
+}
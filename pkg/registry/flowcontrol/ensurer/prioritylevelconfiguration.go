@@ -0,0 +1,479 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	flowcontrolv1beta1 "k8s.io/api/flowcontrol/v1beta1"
+	flowcontrolv1beta2 "k8s.io/api/flowcontrol/v1beta2"
+	flowcontrolv1beta3 "k8s.io/api/flowcontrol/v1beta3"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	flowcontrolapi "k8s.io/kubernetes/pkg/apis/flowcontrol"
+	flowcontrolapisv1 "k8s.io/kubernetes/pkg/apis/flowcontrol/v1"
+	flowcontrolapisv1beta1 "k8s.io/kubernetes/pkg/apis/flowcontrol/v1beta1"
+	flowcontrolapisv1beta2 "k8s.io/kubernetes/pkg/apis/flowcontrol/v1beta2"
+	flowcontrolapisv1beta3 "k8s.io/kubernetes/pkg/apis/flowcontrol/v1beta3"
+	"k8s.io/utils/ptr"
+)
+
+// priorityLevelCodecs mirrors flowSchemaCodecs for PriorityLevelConfiguration.
+var priorityLevelCodecs = map[string]versionCodec[*flowcontrolapi.PriorityLevelConfiguration]{
+	"v1": {
+		newExternal: func() runtime.Object { return &flowcontrolv1.PriorityLevelConfiguration{} },
+		defaultExternal: func(obj runtime.Object) {
+			flowcontrolapisv1.SetObjectDefaults_PriorityLevelConfiguration(obj.(*flowcontrolv1.PriorityLevelConfiguration))
+		},
+		newInternal: func() *flowcontrolapi.PriorityLevelConfiguration { return &flowcontrolapi.PriorityLevelConfiguration{} },
+	},
+	"v1beta3": {
+		newExternal: func() runtime.Object { return &flowcontrolv1beta3.PriorityLevelConfiguration{} },
+		defaultExternal: func(obj runtime.Object) {
+			flowcontrolapisv1beta3.SetObjectDefaults_PriorityLevelConfiguration(obj.(*flowcontrolv1beta3.PriorityLevelConfiguration))
+		},
+		newInternal: func() *flowcontrolapi.PriorityLevelConfiguration { return &flowcontrolapi.PriorityLevelConfiguration{} },
+	},
+	"v1beta2": {
+		newExternal: func() runtime.Object { return &flowcontrolv1beta2.PriorityLevelConfiguration{} },
+		defaultExternal: func(obj runtime.Object) {
+			flowcontrolapisv1beta2.SetObjectDefaults_PriorityLevelConfiguration(obj.(*flowcontrolv1beta2.PriorityLevelConfiguration))
+		},
+		newInternal: func() *flowcontrolapi.PriorityLevelConfiguration { return &flowcontrolapi.PriorityLevelConfiguration{} },
+	},
+	"v1beta1": {
+		newExternal: func() runtime.Object { return &flowcontrolv1beta1.PriorityLevelConfiguration{} },
+		defaultExternal: func(obj runtime.Object) {
+			flowcontrolapisv1beta1.SetObjectDefaults_PriorityLevelConfiguration(obj.(*flowcontrolv1beta1.PriorityLevelConfiguration))
+		},
+		newInternal: func() *flowcontrolapi.PriorityLevelConfiguration { return &flowcontrolapi.PriorityLevelConfiguration{} },
+	},
+}
+
+// PriorityLevelConfigurationEnsurer ensures the specified bootstrap
+// configuration objects. Bootstrap objects are expressed in the
+// internal, version-agnostic representation; the ensurer itself
+// decides which served version to speak on the wire.
+type PriorityLevelConfigurationEnsurer interface {
+	// Ensure installs bootstrap as the current bootstrap set and
+	// synchronously reconciles every object in it, returning the first
+	// error encountered. PriorityLevelConfigurations dropped from the
+	// bootstrap set relative to the previous call are passed to
+	// RemoveAutoUpdateEnabledObjects.
+	Ensure(bootstrap []*flowcontrolapi.PriorityLevelConfiguration) error
+
+	// Run starts the long-running reconciliation loop: it watches the
+	// live PriorityLevelConfiguration objects and keeps the bootstrap
+	// set in sync as user edits or object changes come in, using
+	// workers goroutines to drain the work queue. It blocks until ctx
+	// is done.
+	Run(ctx context.Context, workers int)
+}
+
+// PriorityLevelConfigurationRemover is the interface that wraps the
+// RemoveAutoUpdateEnabledObjects method.
+//
+// RemoveAutoUpdateEnabledObjects removes a set of bootstrap PriorityLevelConfiguration
+// objects specified via their names. The function removes an object
+// only if automatic update of the spec is enabled for it.
+type PriorityLevelConfigurationRemover interface {
+	RemoveAutoUpdateEnabledObjects([]string) error
+}
+
+// NewSuggestedPriorityLevelConfigurationEnsurer returns a PriorityLevelConfigurationEnsurer
+// instance that can be used to ensure a set of suggested PriorityLevelConfiguration
+// configuration objects.
+func NewSuggestedPriorityLevelConfigurationEnsurer(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) (PriorityLevelConfigurationEnsurer, error) {
+	return newPriorityLevelConfigurationEnsurer(discoveryClient, dynamicClient, false)
+}
+
+// NewMandatoryPriorityLevelConfigurationEnsurer returns a PriorityLevelConfigurationEnsurer
+// instance that can be used to ensure a set of mandatory PriorityLevelConfiguration
+// configuration objects.
+func NewMandatoryPriorityLevelConfigurationEnsurer(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) (PriorityLevelConfigurationEnsurer, error) {
+	return newPriorityLevelConfigurationEnsurer(discoveryClient, dynamicClient, true)
+}
+
+// NewPriorityLevelConfigurationRemover returns a PriorityLevelConfigurationRemover
+// instance that can be used to remove a set of PriorityLevelConfiguration configuration objects.
+func NewPriorityLevelConfigurationRemover(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) (PriorityLevelConfigurationRemover, error) {
+	return newPriorityLevelConfigurationEnsurer(discoveryClient, dynamicClient, false)
+}
+
+func newPriorityLevelConfigurationEnsurer(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, mandatory bool) (*plcEnsurer, error) {
+	version, err := discoverFlowControlVersion(discoveryClient)
+	if err != nil {
+		return nil, err
+	}
+	codec, ok := priorityLevelCodecs[version]
+	if !ok {
+		return nil, fmt.Errorf("no PriorityLevelConfiguration codec registered for discovered version %q", version)
+	}
+
+	wrapper := &plcWrapper{
+		resource: dynamicClient.Resource(priorityLevelConfigurationResource(version)),
+		codec:    codec,
+	}
+	e := &plcEnsurer{
+		wrapper:   wrapper,
+		cache:     newSSACache(),
+		mandatory: mandatory,
+		bootstrap: map[string]*flowcontrolapi.PriorityLevelConfiguration{},
+	}
+	e.controller = newBootstrapController(sharedPriorityLevelConfigurationInformer(dynamicClient, priorityLevelConfigurationResource(version)), "prioritylevelconfiguration_ensurer", e.reconcileOne)
+	return e, nil
+}
+
+// priorityLevelConfigurationInformers mirrors flowSchemaInformers for
+// PriorityLevelConfiguration.
+var (
+	priorityLevelConfigurationInformersMu sync.Mutex
+	priorityLevelConfigurationInformers   = map[sharedInformerKey]*startOnceInformer{}
+)
+
+func sharedPriorityLevelConfigurationInformer(dynamicClient dynamic.Interface, resource schema.GroupVersionResource) *startOnceInformer {
+	key := sharedInformerKey{client: dynamicClient, resource: resource}
+	priorityLevelConfigurationInformersMu.Lock()
+	defer priorityLevelConfigurationInformersMu.Unlock()
+
+	if informer, ok := priorityLevelConfigurationInformers[key]; ok {
+		return informer
+	}
+	informer := &startOnceInformer{SharedIndexInformer: newResourceInformer(dynamicClient.Resource(resource))}
+	priorityLevelConfigurationInformers[key] = informer
+	return informer
+}
+
+// GetPriorityLevelConfigurationRemoveCandidates returns a list of PriorityLevelConfiguration
+// object names that are candidates for deletion from the cluster.
+// bootstrap: a set of hard coded PriorityLevelConfiguration configuration objects
+// kube-apiserver maintains in-memory. Candidates are enumerated against
+// whichever version the server currently serves, so removal stays
+// correct even when that differs from the compiled-in bootstrap version.
+//
+// As a side effect, any dangling object that isn't already marked is
+// patched with a PendingDeletion condition and left out of the result:
+// only dangling objects marked by an earlier call are returned, giving
+// operators a window to notice an object before
+// RemoveAutoUpdateEnabledObjects removes it on a later pass.
+func GetPriorityLevelConfigurationRemoveCandidates(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, bootstrap []*flowcontrolapi.PriorityLevelConfiguration) ([]string, error) {
+	version, err := discoverFlowControlVersion(discoveryClient)
+	if err != nil {
+		return nil, err
+	}
+	codec, ok := priorityLevelCodecs[version]
+	if !ok {
+		return nil, fmt.Errorf("no PriorityLevelConfiguration codec registered for discovered version %q", version)
+	}
+	wrapper := &plcWrapper{
+		resource: dynamicClient.Resource(priorityLevelConfigurationResource(version)),
+		codec:    codec,
+	}
+
+	list, err := wrapper.resource.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PriorityLevelConfiguration - %w", err)
+	}
+
+	bootstrapNames := sets.String{}
+	for i := range bootstrap {
+		bootstrapNames.Insert(bootstrap[i].GetName())
+	}
+
+	currentObjects := make([]metav1.Object, 0, len(list.Items))
+	currentByName := make(map[string]*flowcontrolapi.PriorityLevelConfiguration, len(list.Items))
+	for i := range list.Items {
+		current, err := wrapper.codec.fromUnstructured(&list.Items[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert PriorityLevelConfiguration %q - %w", list.Items[i].GetName(), err)
+		}
+		currentObjects = append(currentObjects, current)
+		currentByName[current.GetName()] = current
+	}
+
+	danglingNames := getDanglingBootstrapObjectNames(bootstrapNames, currentObjects)
+	candidates := make([]string, 0, len(danglingNames))
+	for _, name := range danglingNames {
+		current := currentByName[name]
+		alreadyMarked := false
+		for _, condition := range current.Status.Conditions {
+			if condition.Type == conditionPendingDeletion && condition.Status == flowcontrolapi.ConditionTrue {
+				alreadyMarked = true
+			}
+		}
+		if alreadyMarked {
+			candidates = append(candidates, name)
+			continue
+		}
+		if err := wrapper.PatchConditions(current, []flowcontrolapi.PriorityLevelConfigurationCondition{priorityLevelPendingDeletionCondition()}); err != nil {
+			return nil, fmt.Errorf("failed to mark PriorityLevelConfiguration %q as pending deletion - %w", name, err)
+		}
+	}
+
+	return candidates, nil
+}
+
+type plcEnsurer struct {
+	wrapper *plcWrapper
+	// cache lets Ensure skip the apply PATCH entirely when nothing has
+	// changed since the last successful apply for a given
+	// PriorityLevelConfiguration, mirroring fsEnsurer's cache.
+	cache *ssaCache
+	// mandatory is true for the mandatory ensurer, which always owns
+	// the spec regardless of the auto-update annotation.
+	mandatory bool
+
+	// controller drives informer-based reconciliation once Run is
+	// called; Ensure also goes through it so the two stay consistent.
+	controller *bootstrapController
+
+	lock sync.RWMutex
+	// bootstrap is the most recently Ensure'd bootstrap set, keyed by
+	// name, mirroring fsEnsurer.bootstrap.
+	bootstrap map[string]*flowcontrolapi.PriorityLevelConfiguration
+}
+
+func (e *plcEnsurer) Ensure(priorityLevels []*flowcontrolapi.PriorityLevelConfiguration) error {
+	names := make([]string, 0, len(priorityLevels))
+	next := make(map[string]*flowcontrolapi.PriorityLevelConfiguration, len(priorityLevels))
+	for _, priorityLevel := range priorityLevels {
+		// See the comment in fsEnsurer.Ensure for why this copy is needed.
+		copied := priorityLevel.DeepCopy()
+		next[copied.Name] = copied
+		names = append(names, copied.Name)
+	}
+
+	e.lock.Lock()
+	previous := e.bootstrap
+	e.bootstrap = next
+	e.lock.Unlock()
+
+	ensureErr := e.controller.ensureSync(names)
+
+	var dropped []string
+	for name := range previous {
+		if _, ok := next[name]; !ok {
+			dropped = append(dropped, name)
+		}
+	}
+	if len(dropped) == 0 {
+		return ensureErr
+	}
+	if err := e.RemoveAutoUpdateEnabledObjects(dropped); err != nil && ensureErr == nil {
+		ensureErr = err
+	}
+	return ensureErr
+}
+
+// Run starts the informer-driven reconciliation loop and blocks until
+// ctx is done.
+func (e *plcEnsurer) Run(ctx context.Context, workers int) {
+	e.controller.run(ctx, workers)
+}
+
+// reconcileOne mirrors fsEnsurer.reconcileOne for PriorityLevelConfiguration.
+func (e *plcEnsurer) reconcileOne(name string) error {
+	e.lock.RLock()
+	bootstrap, ok := e.bootstrap[name]
+	e.lock.RUnlock()
+	if !ok {
+		return nil
+	}
+	return e.ensureOne(bootstrap.DeepCopy())
+}
+
+// ensureOne applies a single bootstrap PriorityLevelConfiguration via
+// server-side apply, skipping the PATCH call altogether when e.cache
+// shows the live object already reflects this bootstrap spec. It also
+// keeps the object's ManagedByBootstrap and SpecInSync conditions up
+// to date.
+func (e *plcEnsurer) ensureOne(bootstrap *flowcontrolapi.PriorityLevelConfiguration) error {
+	name := bootstrap.GetName()
+
+	current, err := e.wrapper.Get(name)
+	notFound := apierrors.IsNotFound(err)
+	if err != nil && !notFound {
+		return err
+	}
+
+	autoUpdate := true
+	var resourceVersion string
+	if !notFound {
+		resourceVersion = current.GetResourceVersion()
+		if !e.mandatory {
+			autoUpdate = isAutoUpdateEnabled(current)
+		}
+	}
+
+	if !autoUpdate {
+		defaultedBootstrap, err := e.wrapper.Defaulted(bootstrap)
+		if err != nil {
+			return err
+		}
+		specChanged := !equality.Semantic.DeepEqual(defaultedBootstrap.Spec, current.Spec)
+		var message string
+		if specChanged {
+			message = driftMessage(defaultedBootstrap.Spec, current.Spec)
+		}
+		return e.wrapper.PatchConditions(current, []flowcontrolapi.PriorityLevelConfigurationCondition{
+			priorityLevelManagedByBootstrapCondition(false),
+			priorityLevelSpecInSyncCondition(!specChanged, message),
+		})
+	}
+
+	defaultedBootstrap, err := e.wrapper.Defaulted(bootstrap)
+	if err != nil {
+		return err
+	}
+
+	hash, err := ssaHash(resourceVersion, defaultedBootstrap.Spec)
+	if err != nil {
+		return err
+	}
+	if cached, ok := e.cache.Get(name); ok && cached == hash {
+		return nil
+	}
+
+	applied, err := e.wrapper.Apply(bootstrap)
+	if err != nil {
+		e.cache.Evict(name)
+		return err
+	}
+
+	newHash, err := ssaHash(applied.GetResourceVersion(), defaultedBootstrap.Spec)
+	if err != nil {
+		return err
+	}
+	e.cache.Set(name, newHash)
+
+	return e.wrapper.PatchConditions(applied, []flowcontrolapi.PriorityLevelConfigurationCondition{
+		priorityLevelManagedByBootstrapCondition(true),
+		priorityLevelSpecInSyncCondition(true, ""),
+		priorityLevelPendingDeletionClearedCondition(),
+	})
+}
+
+func (e *plcEnsurer) RemoveAutoUpdateEnabledObjects(priorityLevels []string) error {
+	for _, name := range priorityLevels {
+		if err := e.removeOne(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *plcEnsurer) removeOne(name string) error {
+	current, err := e.wrapper.Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !isAutoUpdateEnabled(current) {
+		return nil
+	}
+	if err := e.wrapper.Delete(name); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// plcWrapper abstracts all PriorityLevelConfiguration specific logic, with
+// this we can manage all boiler plate code in one place. It speaks
+// whichever served version codec was resolved at construction time,
+// translating to and from the internal representation at its boundary.
+type plcWrapper struct {
+	resource dynamic.NamespaceableResourceInterface
+	codec    versionCodec[*flowcontrolapi.PriorityLevelConfiguration]
+}
+
+func (plc *plcWrapper) TypeName() string {
+	return "PriorityLevelConfiguration"
+}
+
+// Apply issues a server-side apply PATCH with the bootstrap object as
+// the intent, creating the PriorityLevelConfiguration if it does not
+// yet exist.
+func (plc *plcWrapper) Apply(bootstrap *flowcontrolapi.PriorityLevelConfiguration) (*flowcontrolapi.PriorityLevelConfiguration, error) {
+	u, err := plc.codec.toUnstructured(bootstrap)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal PriorityLevelConfiguration %q for server-side apply - %w", bootstrap.Name, err)
+	}
+
+	applied, err := plc.resource.Patch(context.TODO(), bootstrap.Name, types.ApplyPatchType, body, metav1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        ptr.To(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plc.codec.fromUnstructured(applied)
+}
+
+// PatchConditions applies conditions to object's status, mirroring
+// flowSchemaWrapper.PatchConditions.
+func (plc *plcWrapper) PatchConditions(object *flowcontrolapi.PriorityLevelConfiguration, conditions []flowcontrolapi.PriorityLevelConfigurationCondition) error {
+	updated := object.DeepCopy()
+	for _, condition := range conditions {
+		setPriorityLevelConfigurationCondition(&updated.Status, condition)
+	}
+	if equality.Semantic.DeepEqual(updated.Status, object.Status) {
+		return nil
+	}
+
+	u, err := plc.codec.toUnstructured(updated)
+	if err != nil {
+		return err
+	}
+	_, err = plc.resource.UpdateStatus(context.TODO(), u, metav1.UpdateOptions{FieldManager: fieldManager})
+	return err
+}
+
+func (plc *plcWrapper) Get(name string) (*flowcontrolapi.PriorityLevelConfiguration, error) {
+	u, err := plc.resource.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return plc.codec.fromUnstructured(u)
+}
+
+func (plc *plcWrapper) Delete(name string) error {
+	return plc.resource.Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+// Defaulted mirrors flowSchemaWrapper.Defaulted for PriorityLevelConfiguration.
+func (plc *plcWrapper) Defaulted(bootstrap *flowcontrolapi.PriorityLevelConfiguration) (*flowcontrolapi.PriorityLevelConfiguration, error) {
+	return plc.codec.defaulted(bootstrap)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// ssaCacheMaxEntries bounds the number of namespace/name entries the
+// ensurer will remember between apply calls. A single kube-apiserver
+// manages at most a few hundred bootstrap FlowSchema/PriorityLevelConfiguration
+// objects, so this comfortably covers that with room to spare.
+const ssaCacheMaxEntries = 1024
+
+// ssaCache remembers, for each bootstrap object the ensurer manages, a
+// hash that summarizes the last successful server-side apply: the live
+// object's resourceVersion combined with the intended spec. If neither
+// has changed since the last apply, the ensurer can skip the PATCH call
+// entirely.
+//
+// ssaCache is safe for concurrent use by multiple goroutines, matching
+// the concurrent-Ensure concern called out in fsEnsurer.Ensure.
+type ssaCache struct {
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// ssaCacheEntry is the value stored in the LRU's linked list.
+type ssaCacheEntry struct {
+	key  string
+	hash string
+}
+
+func newSSACache() *ssaCache {
+	return &ssaCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the cached hash for key, if any, and marks it as
+// recently used.
+func (c *ssaCache) Get(key string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*ssaCacheEntry).hash, true
+}
+
+// Set records hash as the last-applied hash for key, evicting the
+// least recently used entry if the cache is at capacity.
+func (c *ssaCache) Set(key, hash string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*ssaCacheEntry).hash = hash
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&ssaCacheEntry{key: key, hash: hash})
+	if c.order.Len() <= ssaCacheMaxEntries {
+		return
+	}
+	oldest := c.order.Back()
+	c.order.Remove(oldest)
+	delete(c.entries, oldest.Value.(*ssaCacheEntry).key)
+}
+
+// Evict drops any cached hash for key. It must be called whenever an
+// apply for key fails (e.g. a conflict) so that the next Ensure pass
+// does not mistakenly believe the object is already in sync.
+func (c *ssaCache) Evict(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	c.order.Remove(element)
+	delete(c.entries, key)
+}
+
+// ssaHash computes hash(resourceVersion + "|" + fnv(specJSON)), the
+// value the ensurer uses to decide whether a server-side apply can be
+// skipped. resourceVersion should come from the cached live object;
+// spec is the intended (already-defaulted) bootstrap spec.
+func ssaHash(resourceVersion string, spec interface{}) (string, error) {
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal spec for ssa cache hash - %w", err)
+	}
+
+	specHash := fnv.New64a()
+	_, _ = specHash.Write(specJSON)
+
+	combined := fnv.New64a()
+	_, _ = fmt.Fprintf(combined, "%s|%x", resourceVersion, specHash.Sum64())
+	return fmt.Sprintf("%x", combined.Sum64()), nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSSACacheGetSetEvict(t *testing.T) {
+	c := newSSACache()
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	c.Set("a", "hash-a")
+	if got, ok := c.Get("a"); !ok || got != "hash-a" {
+		t.Fatalf("got (%q, %v), want (%q, true)", got, ok, "hash-a")
+	}
+
+	c.Set("a", "hash-a-2")
+	if got, ok := c.Get("a"); !ok || got != "hash-a-2" {
+		t.Fatalf("Set did not overwrite the existing entry: got (%q, %v)", got, ok)
+	}
+
+	c.Evict("a")
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected a miss after Evict")
+	}
+
+	// Evicting a key that was never set must be a no-op, not a panic.
+	c.Evict("does-not-exist")
+}
+
+func TestSSACacheLRUEviction(t *testing.T) {
+	c := newSSACache()
+
+	for i := 0; i < ssaCacheMaxEntries; i++ {
+		c.Set(fmt.Sprintf("key-%d", i), "hash")
+	}
+
+	// Touch key-0 so it becomes the most recently used entry, and should
+	// survive the eviction the next Set triggers.
+	if _, ok := c.Get("key-0"); !ok {
+		t.Fatal("expected key-0 to still be cached")
+	}
+
+	// Pushes the cache one over capacity; the least recently used entry
+	// (key-1, since key-0 was just touched) must be evicted.
+	c.Set("key-overflow", "hash")
+
+	if _, ok := c.Get("key-0"); !ok {
+		t.Error("key-0 was recently used and should not have been evicted")
+	}
+	if _, ok := c.Get("key-1"); ok {
+		t.Error("key-1 was the least recently used entry and should have been evicted")
+	}
+	if _, ok := c.Get("key-overflow"); !ok {
+		t.Error("expected the newly set entry to be cached")
+	}
+}
+
+func TestSSAHash(t *testing.T) {
+	hash1, err := ssaHash("1", map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hash1Again, err := ssaHash("1", map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash1 != hash1Again {
+		t.Error("ssaHash should be deterministic for identical inputs")
+	}
+
+	hashDifferentResourceVersion, err := ssaHash("2", map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash1 == hashDifferentResourceVersion {
+		t.Error("ssaHash should change when resourceVersion changes")
+	}
+
+	hashDifferentSpec, err := ssaHash("1", map[string]string{"a": "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash1 == hashDifferentSpec {
+		t.Error("ssaHash should change when spec changes")
+	}
+
+	if _, err := ssaHash("1", make(chan int)); err == nil {
+		t.Error("expected an error marshaling a spec that cannot be JSON-encoded")
+	}
+}
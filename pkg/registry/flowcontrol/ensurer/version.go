@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	legacyscheme "k8s.io/kubernetes/pkg/api/legacyscheme"
+	// Registers the flowcontrol types and conversions in legacyscheme,
+	// which versionCodec converts through.
+	_ "k8s.io/kubernetes/pkg/apis/flowcontrol/install"
+)
+
+// fieldManager identifies the ensurer to server-side apply.
+const fieldManager = "api-priority-and-fairness-config-producer-v1"
+
+// flowControlGroupName is the API group both FlowSchema and
+// PriorityLevelConfiguration belong to, across every version.
+const flowControlGroupName = "flowcontrol.apiserver.k8s.io"
+
+// flowControlVersionPreference lists the versions the ensurer knows how
+// to bootstrap against, most preferred first. discoverFlowControlVersion
+// picks the first of these the live apiserver actually serves, so a
+// single kube-apiserver binary can bootstrap against an older or newer
+// cluster during version skew without a code change.
+var flowControlVersionPreference = []string{"v1", "v1beta3", "v1beta2", "v1beta1"}
+
+// discoverFlowControlVersion asks client which versions of the
+// flowcontrol.apiserver.k8s.io group the server currently serves, and
+// returns the most preferred one the ensurer supports.
+func discoverFlowControlVersion(client discovery.DiscoveryInterface) (string, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return "", fmt.Errorf("failed to discover server groups - %w", err)
+	}
+
+	served := sets.NewString()
+	for i := range groups.Groups {
+		group := &groups.Groups[i]
+		if group.Name != flowControlGroupName {
+			continue
+		}
+		for _, version := range group.Versions {
+			served.Insert(version.Version)
+		}
+	}
+
+	for _, version := range flowControlVersionPreference {
+		if served.Has(version) {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("server does not serve any supported version of %s (tried %v)", flowControlGroupName, flowControlVersionPreference)
+}
+
+func flowSchemaResource(version string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: flowControlGroupName, Version: version, Resource: "flowschemas"}
+}
+
+func priorityLevelConfigurationResource(version string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: flowControlGroupName, Version: version, Resource: "prioritylevelconfigurations"}
+}
+
+// getDanglingBootstrapObjectNames returns the names, among currentObjects,
+// of those that are not in bootstrapNames and have not opted out of
+// ensurer-managed removal via the auto-update annotation.
+func getDanglingBootstrapObjectNames(bootstrapNames sets.String, currentObjects []metav1.Object) []string {
+	danglingNames := sets.String{}
+	for i := range currentObjects {
+		name := currentObjects[i].GetName()
+		if bootstrapNames.Has(name) {
+			continue
+		}
+		if !isAutoUpdateEnabled(currentObjects[i]) {
+			continue
+		}
+		danglingNames.Insert(name)
+	}
+	return danglingNames.List()
+}
+
+// versionCodec converts between the internal, version-agnostic
+// representation T of a bootstrap object and whichever served external
+// version the ensurer discovered at construction time. Keeping this
+// generic lets FlowSchema and PriorityLevelConfiguration - two distinct
+// internal types with an identical conversion story - share one
+// implementation instead of duplicating it per kind.
+type versionCodec[T runtime.Object] struct {
+	newExternal     func() runtime.Object
+	defaultExternal func(runtime.Object)
+	newInternal     func() T
+}
+
+// toExternal converts internal to the served external version and
+// applies that version's defaulting function, the same defaulting the
+// live object itself would have gone through. The returned object also
+// carries its apiVersion/kind: callers that marshal it (server-side
+// apply, status PATCH) need it set, since the scheme's Convert and
+// defaulting functions never populate embedded TypeMeta themselves.
+func (c versionCodec[T]) toExternal(internal T) (runtime.Object, error) {
+	external := c.newExternal()
+	if err := legacyscheme.Scheme.Convert(internal, external, nil); err != nil {
+		return nil, fmt.Errorf("failed to convert to external version - %w", err)
+	}
+	c.defaultExternal(external)
+
+	gvks, _, err := legacyscheme.Scheme.ObjectKinds(external)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine GroupVersionKind for %T - %w", external, err)
+	}
+	external.GetObjectKind().SetGroupVersionKind(gvks[0])
+	return external, nil
+}
+
+func (c versionCodec[T]) toInternal(external runtime.Object) (T, error) {
+	internal := c.newInternal()
+	if err := legacyscheme.Scheme.Convert(external, internal, nil); err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to convert to internal version - %w", err)
+	}
+	return internal, nil
+}
+
+// defaulted round-trips internal through the served external version
+// and back, so the result reflects that version's defaulting - this is
+// what lets callers like flowSchemaWrapper.Defaulted compare like with
+// like no matter which version the bootstrap spec was authored against.
+func (c versionCodec[T]) defaulted(internal T) (T, error) {
+	external, err := c.toExternal(internal)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return c.toInternal(external)
+}
+
+func (c versionCodec[T]) toUnstructured(internal T) (*unstructured.Unstructured, error) {
+	external, err := c.toExternal(internal)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(external)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert to unstructured - %w", err)
+	}
+	return &unstructured.Unstructured{Object: obj}, nil
+}
+
+func (c versionCodec[T]) fromUnstructured(u *unstructured.Unstructured) (T, error) {
+	external := c.newExternal()
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, external); err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to convert from unstructured - %w", err)
+	}
+	return c.toInternal(external)
+}
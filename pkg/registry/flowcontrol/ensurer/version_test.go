@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ensurer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	flowcontrolapi "k8s.io/kubernetes/pkg/apis/flowcontrol"
+)
+
+// stubDiscovery implements discovery.DiscoveryInterface by embedding a
+// nil instance of it and overriding only ServerGroups, the single
+// method discoverFlowControlVersion calls.
+type stubDiscovery struct {
+	discovery.DiscoveryInterface
+	groups *metav1.APIGroupList
+	err    error
+}
+
+func (s *stubDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	return s.groups, s.err
+}
+
+func groupList(versions ...string) *metav1.APIGroupList {
+	group := metav1.APIGroup{Name: flowControlGroupName}
+	for _, v := range versions {
+		group.Versions = append(group.Versions, metav1.GroupVersionForDiscovery{Version: v})
+	}
+	return &metav1.APIGroupList{Groups: []metav1.APIGroup{group}}
+}
+
+func TestDiscoverFlowControlVersionPreference(t *testing.T) {
+	cases := []struct {
+		name    string
+		served  []string
+		want    string
+		wantErr bool
+	}{
+		{name: "prefers v1 when everything is served", served: []string{"v1beta1", "v1beta2", "v1beta3", "v1"}, want: "v1"},
+		{name: "falls back to v1beta3 when v1 is not served", served: []string{"v1beta1", "v1beta2", "v1beta3"}, want: "v1beta3"},
+		{name: "falls back to the oldest served version", served: []string{"v1beta1"}, want: "v1beta1"},
+		{name: "no supported version served is an error", served: []string{"v2alpha1"}, wantErr: true},
+		{name: "group missing entirely is an error", served: nil, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var groups *metav1.APIGroupList
+			if tc.served != nil {
+				groups = groupList(tc.served...)
+			} else {
+				groups = &metav1.APIGroupList{}
+			}
+			got, err := discoverFlowControlVersion(&stubDiscovery{groups: groups})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverFlowControlVersionPropagatesDiscoveryError(t *testing.T) {
+	wantErr := errors.New("discovery unavailable")
+	_, err := discoverFlowControlVersion(&stubDiscovery{err: wantErr})
+	if err == nil || !strings.Contains(err.Error(), wantErr.Error()) {
+		t.Fatalf("expected the discovery error to be wrapped, got %v", err)
+	}
+}
+
+func TestGetDanglingBootstrapObjectNames(t *testing.T) {
+	bootstrapNames := sets.NewString("keep-me")
+
+	current := []metav1.Object{
+		&metav1.ObjectMeta{Name: "keep-me"},
+		&metav1.ObjectMeta{Name: "dangling-auto-update-enabled"},
+		&metav1.ObjectMeta{
+			Name:        "dangling-auto-update-disabled",
+			Annotations: map[string]string{autoUpdateAnnotationKey: "false"},
+		},
+	}
+
+	got := getDanglingBootstrapObjectNames(bootstrapNames, current)
+
+	want := []string{"dangling-auto-update-enabled"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestVersionCodecRoundTrip(t *testing.T) {
+	codec, ok := flowSchemaCodecs["v1"]
+	if !ok {
+		t.Fatal("expected a v1 FlowSchema codec to be registered")
+	}
+
+	internal := &flowcontrolapi.FlowSchema{}
+	internal.Name = "test-flow-schema"
+
+	u, err := codec.toUnstructured(internal)
+	if err != nil {
+		t.Fatalf("toUnstructured: %v", err)
+	}
+	if u.GetAPIVersion() != flowControlGroupName+"/v1" || u.GetKind() != "FlowSchema" {
+		t.Errorf("got apiVersion=%q kind=%q, want %q/v1 and FlowSchema", u.GetAPIVersion(), u.GetKind(), flowControlGroupName)
+	}
+	if u.GetName() != internal.Name {
+		t.Errorf("got name %q, want %q", u.GetName(), internal.Name)
+	}
+
+	roundTripped, err := codec.fromUnstructured(u)
+	if err != nil {
+		t.Fatalf("fromUnstructured: %v", err)
+	}
+	if roundTripped.GetName() != internal.Name {
+		t.Errorf("round trip changed the name: got %q, want %q", roundTripped.GetName(), internal.Name)
+	}
+}